@@ -12,8 +12,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/CADDE-sip/cdl-chaincode-go/validator"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"log"
+	"sort"
+	"time"
 )
 
 //--------------------------------
@@ -25,33 +29,141 @@ type CdlChainCode struct {
 	contractapi.Contract
 }
 
+// AdminMSPKey ブートストラップ管理者のMSP IDを保存するWorldStateの予約キー
+const AdminMSPKey = "acl~admin"
+
+// cdlEventValidator RegistCDLEvent/RegistUpdateCDLEvent/RegistCDLEventBatchが実行するバリデータチェーン
+var cdlEventValidator = validator.NewChain(
+	validator.NewSchemaValidator(),
+	validator.NewIdentityValidator(),
+)
+
 //------------------------------------
 // チェーンコード本体の実装
 //------------------------------------
 
 // Init チェーンコード配備時に実行される初期化関数
-func (cc *CdlChainCode) Init(ctx contractapi.TransactionContextInterface) {
+//
+// @param ctx the transaction context
+// @param adminMspId ACL(GrantCDLEventRole/RevokeCDLEventRole)を管理できるブートストラップ管理者のMSP ID
+func (cc *CdlChainCode) Init(ctx contractapi.TransactionContextInterface, adminMspId string) error {
+	myFunc := "Init"
+	stub := ctx.GetStub()
+
 	log.Print("init: called...")
 
-	// 初期化処理無し
+	if adminMspId == "" {
+		msg := "[" + myFunc + "] adminMspId must not be empty"
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	// ブートストラップ管理者のMSP IDをWorldStateに記録
+	err := stub.PutState(AdminMSPKey, []byte(adminMspId))
+	if err != nil {
+		msg := "[" + myFunc + "] PutState(AdminMSPKey) fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
 
 	log.Print("init: done.")
+
+	// 異常が無ければ正常復帰する
+	return nil
 }
 
 //---------------------------------
 // invoke系の関数
 //---------------------------------
 
+// CDLEventRegisteredEventName RegistCDLEvent実行時に発行するFabricチェーンコードイベント名
+const CDLEventRegisteredEventName = "CDLEventRegistered"
+
+// CDLEventUpdatedEventName RegistUpdateCDLEvent実行時に発行するFabricチェーンコードイベント名
+const CDLEventUpdatedEventName = "CDLEventUpdated"
+
+// CDLEventNotification チェーンコードイベントのペイロードを表す構造体
+type CDLEventNotification struct {
+	Key          string   `json:"key"`
+	MspId        string   `json:"mspId"`
+	Timestamp    string   `json:"timestamp"`
+	Predecessors []string `json:"predecessors"`
+}
+
+// setCDLEvent キーの登録・更新をFabricチェーンコードイベントとして発行する
+//
+// @param ctx the transaction context
+// @param myFunc the name of the calling function (for log/error messages)
+// @param eventName the name of the Fabric chaincode event to emit
+// @param key the key for the CDL Event
+// @param predecessors the keys of the predecessor events mutated alongside key
+func setCDLEvent(ctx contractapi.TransactionContextInterface, myFunc string, eventName string, key string, predecessors []string) error {
+	stub := ctx.GetStub()
+
+	// 発行者のMSP IDを取得
+	mspId, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		msg := "[" + myFunc + "] GetClientIdentity().GetMSPID() fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	// トランザクションタイムスタンプを取得
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		msg := "[" + myFunc + "] GetTxTimestamp() fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	notification := CDLEventNotification{
+		Key:          key,
+		MspId:        mspId,
+		Timestamp:    time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC().Format(time.RFC3339Nano),
+		Predecessors: predecessors,
+	}
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		msg := "[" + myFunc + "] json.Marshal(notification) fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	// チェーンコードイベントを発行
+	err = stub.SetEvent(eventName, payload)
+	if err != nil {
+		msg := "[" + myFunc + "] SetEvent(eventName) fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	return nil
+}
+
 // regist CDL Event to the Block-Chain
 //
 // @param ctx the transaction context
 // @param key the key for the CDL Event
 // @param jsonString the make of the new event
 func (cc *CdlChainCode) RegistCDLEvent(ctx contractapi.TransactionContextInterface, key string, jsonString string) (error) {
-	myFunc := "RegistCDLEvent"
+	return cc.registCDLEvent(ctx, "RegistCDLEvent", key, jsonString, CDLEventRegisteredEventName)
+}
+
+// regist CDL Event to the Block-Chain, emitting a caller-chosen Fabric chaincode event
+//
+// @param ctx the transaction context
+// @param key the key for the CDL Event
+// @param jsonString the make of the new event
+// @param eventTag the name of the Fabric chaincode event to emit instead of CDLEventRegistered
+func (cc *CdlChainCode) RegistCDLEventWithTag(ctx contractapi.TransactionContextInterface, key string, jsonString string, eventTag string) (error) {
+	return cc.registCDLEvent(ctx, "RegistCDLEventWithTag", key, jsonString, eventTag)
+}
+
+// registCDLEvent RegistCDLEvent / RegistCDLEventWithTag共通の登録処理
+func (cc *CdlChainCode) registCDLEvent(ctx contractapi.TransactionContextInterface, myFunc string, key string, jsonString string, eventName string) error {
 	stub := ctx.GetStub()
 
-	log.Print("cdl-chaincode : RegistCDLEvent() called key=" + key)
+	log.Print("cdl-chaincode : " + myFunc + "() called key=" + key)
 
 	// イベントが未登録であることをチェック
 	state, err := stub.GetState(key)
@@ -66,7 +178,15 @@ func (cc *CdlChainCode) RegistCDLEvent(ctx contractapi.TransactionContextInterfa
 		log.Print(msg)
 		return fmt.Errorf(msg)
 	}
-	
+
+	// スキーマ検証・アクセス制御のバリデータチェーンを実行
+	err = cdlEventValidator.Validate(ctx, jsonString)
+	if err != nil {
+		msg := "[" + myFunc + "] " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
 	// WorldStateにイベント情報を登録
 	err = stub.PutState(key, []byte(jsonString))
 	if err != nil {
@@ -76,7 +196,13 @@ func (cc *CdlChainCode) RegistCDLEvent(ctx contractapi.TransactionContextInterfa
 		return fmt.Errorf(msg)
 	}
 
-    log.Print("cdl-chaincode : RegistCDLEvent() end key=" + key)
+	// チェーンコードイベントを発行
+	err = setCDLEvent(ctx, myFunc, eventName, key, []string{})
+	if err != nil {
+		return err
+	}
+
+    log.Print("cdl-chaincode : " + myFunc + "() end key=" + key)
 
 	// 異常が無ければ正常復帰する
 	return nil
@@ -108,6 +234,14 @@ func (cc *CdlChainCode) RegistUpdateCDLEvent(ctx contractapi.TransactionContextI
 		return fmt.Errorf(msg)
 	}
 
+	// スキーマ検証・アクセス制御のバリデータチェーンを実行
+	err = cdlEventValidator.Validate(ctx, jsonString)
+	if err != nil {
+		msg := "[" + myFunc + "] " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
 	// イベント登録
 	err = stub.PutState(key, []byte(jsonString))
 	if err != nil {
@@ -122,6 +256,7 @@ func (cc *CdlChainCode) RegistUpdateCDLEvent(ctx contractapi.TransactionContextI
 	if err != nil {
 		return fmt.Errorf("[" + myFunc + "] json.Unmarshal(updates) Error: " + err.Error())
 	}
+	predecessors := make([]string, 0, len(updateMap))
 	for k, v := range updateMap {
 		// 更新する前イベントが登録済みかをチェック
 		state, err = stub.GetState(k)
@@ -137,6 +272,14 @@ func (cc *CdlChainCode) RegistUpdateCDLEvent(ctx contractapi.TransactionContextI
 			return fmt.Errorf(msg)
 		}
 
+		// スキーマ検証・アクセス制御のバリデータチェーンを実行
+		err = cdlEventValidator.Validate(ctx, v)
+		if err != nil {
+			msg := "[" + myFunc + "] " + err.Error()
+			log.Print(msg)
+			return fmt.Errorf(msg)
+		}
+
 		// 前イベント更新
 		err = stub.PutState(k, []byte(v))
 		if err != nil {
@@ -145,6 +288,16 @@ func (cc *CdlChainCode) RegistUpdateCDLEvent(ctx contractapi.TransactionContextI
 			log.Print(msg)
 			return fmt.Errorf(msg)
 		}
+		predecessors = append(predecessors, k)
+	}
+	// updateMapのイテレート順はGoのmapの仕様上不定であるため、
+	// 裏書きピア間でイベントペイロードが一致するようソートしておく
+	sort.Strings(predecessors)
+
+	// チェーンコードイベントを発行
+	err = setCDLEvent(ctx, myFunc, CDLEventUpdatedEventName, key, predecessors)
+	if err != nil {
+		return err
 	}
 
 	log.Print("cdl-chaincode : RegistUpdateCDLEvent() end key=" + key)
@@ -153,6 +306,377 @@ func (cc *CdlChainCode) RegistUpdateCDLEvent(ctx contractapi.TransactionContextI
 	return nil
 }
 
+// registCDLEventBatchEntry RegistCDLEventBatchの入力に含まれる1件分のイベント登録情報
+type registCDLEventBatchEntry struct {
+	Key          string            `json:"key"`
+	Value        string            `json:"value"`
+	Predecessors map[string]string `json:"predecessors"`
+}
+
+// registCDLEventBatchRequest RegistCDLEventBatchの入力形式
+type registCDLEventBatchRequest struct {
+	Events []registCDLEventBatchEntry `json:"events"`
+}
+
+// RegistCDLEventBatchEventResult RegistCDLEventBatchの応答に含まれる1件分の登録結果
+type RegistCDLEventBatchEventResult struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+}
+
+// RegistCDLEventBatchResult RegistCDLEventBatchの応答形式
+type RegistCDLEventBatchResult struct {
+	Events      []RegistCDLEventBatchEventResult `json:"events"`
+	KeysWritten []string                         `json:"keysWritten"`
+}
+
+// regist a batch of CDL Events (and their predecessor updates) to the Block-Chain in a single transaction
+//
+// Fabricのトランザクションはスナップショット一貫性を持つread-setで実行されるため、
+// 事前チェックを全件通過した場合のみPutStateを行うことで、バッチ全体のall-or-nothingを実現する。
+//
+// @param ctx the transaction context
+// @param batchJson {"events":[{"key":..,"value":..,"predecessors":{key:value,...}}, ...]}
+// @return summary of the batch registration (Json String)
+func (cc *CdlChainCode) RegistCDLEventBatch(ctx contractapi.TransactionContextInterface, batchJson string) (string, error) {
+	myFunc := "RegistCDLEventBatch"
+	stub := ctx.GetStub()
+
+	log.Print("cdl-chaincode : RegistCDLEventBatch() called")
+
+	var request registCDLEventBatchRequest
+	err := json.Unmarshal([]byte(batchJson), &request)
+	if err != nil {
+		msg := "[" + myFunc + "] json.Unmarshal(batchJson) Error: " + err.Error()
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+	if len(request.Events) == 0 {
+		msg := "[" + myFunc + "] The specified batch's size is zero."
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+
+	// 事前チェック: バッチ内で書き込み対象キー(Key及びPredecessorsの各キー)が
+	// 重複していないことを確認する。重複を見逃すと、後勝ちのPutStateが
+	// 先に書き込んだ内容を無言で上書きしてしまい、all-or-nothingの前提が崩れる。
+	writeKeySeen := make(map[string]bool)
+	for _, event := range request.Events {
+		if writeKeySeen[event.Key] {
+			msg := fmt.Sprintf("["+myFunc+"] cdleventid '%s' is written more than once in the batch", event.Key)
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+		writeKeySeen[event.Key] = true
+
+		for predecessorKey := range event.Predecessors {
+			if writeKeySeen[predecessorKey] {
+				msg := fmt.Sprintf("["+myFunc+"] cdleventid '%s' is written more than once in the batch", predecessorKey)
+				log.Print(msg)
+				return "", fmt.Errorf(msg)
+			}
+			writeKeySeen[predecessorKey] = true
+		}
+	}
+
+	// 事前チェック: 新規登録するイベントが未登録であることを確認
+	for _, event := range request.Events {
+		state, err := stub.GetState(event.Key)
+		if err != nil {
+			msg := fmt.Sprintf("["+myFunc+"] "+
+				"GetState(key) %v, Error: "+ err.Error(), event.Key)
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+		if state != nil {
+			msg := fmt.Sprintf("cdleventid '%s' already exists", event.Key)
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+
+		// スキーマ検証・アクセス制御のバリデータチェーンを実行
+		err = cdlEventValidator.Validate(ctx, event.Value)
+		if err != nil {
+			msg := "[" + myFunc + "] " + err.Error()
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+
+		// 事前チェック: 更新する前イベントが登録済みであることを確認
+		for predecessorKey, predecessorValue := range event.Predecessors {
+			predecessorState, err := stub.GetState(predecessorKey)
+			if err != nil {
+				msg := fmt.Sprintf("["+myFunc+"] "+
+					"GetState(key) %v, Error: "+ err.Error(), predecessorKey)
+				log.Print(msg)
+				return "", fmt.Errorf(msg)
+			}
+			if predecessorState == nil {
+				msg := fmt.Sprintf("cdleventid '%s' not found", predecessorKey)
+				log.Print(msg)
+				return "", fmt.Errorf(msg)
+			}
+
+			// スキーマ検証・アクセス制御のバリデータチェーンを実行
+			err = cdlEventValidator.Validate(ctx, predecessorValue)
+			if err != nil {
+				msg := "[" + myFunc + "] " + err.Error()
+				log.Print(msg)
+				return "", fmt.Errorf(msg)
+			}
+		}
+	}
+
+	// 事前チェックを全件通過したのでPutStateを実行する
+	result := RegistCDLEventBatchResult{
+		Events:      make([]RegistCDLEventBatchEventResult, 0, len(request.Events)),
+		KeysWritten: make([]string, 0),
+	}
+	for _, event := range request.Events {
+		err = stub.PutState(event.Key, []byte(event.Value))
+		if err != nil {
+			msg := "[" + myFunc + "] " +
+				"PutState(key) fail, Error: " + err.Error()
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+		result.KeysWritten = append(result.KeysWritten, event.Key)
+
+		predecessorKeys := make([]string, 0, len(event.Predecessors))
+		for predecessorKey, predecessorValue := range event.Predecessors {
+			err = stub.PutState(predecessorKey, []byte(predecessorValue))
+			if err != nil {
+				msg := "[" + myFunc + "] " +
+					"PutState(key) fail, Error: " + err.Error()
+				log.Print(msg)
+				return "", fmt.Errorf(msg)
+			}
+			result.KeysWritten = append(result.KeysWritten, predecessorKey)
+			predecessorKeys = append(predecessorKeys, predecessorKey)
+		}
+		// updateMap/Predecessorsのイテレート順はGoのmapの仕様上不定であるため、
+		// 裏書きピア間でイベントペイロードが一致するようソートしておく
+		sort.Strings(predecessorKeys)
+
+		// 単独APIと同様にチェーンコードイベントを発行する
+		// (前イベントが無ければCDLEventRegistered、あればCDLEventUpdatedとして通知する)
+		eventName := CDLEventRegisteredEventName
+		if len(predecessorKeys) > 0 {
+			eventName = CDLEventUpdatedEventName
+		}
+		err = setCDLEvent(ctx, myFunc, eventName, event.Key, predecessorKeys)
+		if err != nil {
+			return "", err
+		}
+
+		result.Events = append(result.Events, RegistCDLEventBatchEventResult{
+			Key:    event.Key,
+			Status: "registered",
+		})
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		msg := "[" + myFunc + "] json.Marshal(result) Error: " + err.Error()
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+
+	log.Print("cdl-chaincode : RegistCDLEventBatch() end")
+
+	// 異常が無ければ正常復帰する
+	return string(resultBytes), nil
+}
+
+// requireBootstrapAdmin 呼び出し元がInitで記録したブートストラップ管理者MSPであることを確認する
+func requireBootstrapAdmin(ctx contractapi.TransactionContextInterface, myFunc string) error {
+	stub := ctx.GetStub()
+
+	adminMspIdBytes, err := stub.GetState(AdminMSPKey)
+	if err != nil {
+		msg := "[" + myFunc + "] GetState(AdminMSPKey) fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+	if adminMspIdBytes == nil {
+		msg := "[" + myFunc + "] bootstrap admin MSP is not initialized"
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	callerMspId, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		msg := "[" + myFunc + "] GetClientIdentity().GetMSPID() fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	if callerMspId != string(adminMspIdBytes) {
+		msg := fmt.Sprintf("["+myFunc+"] MSP '%s' is not the bootstrap admin", callerMspId)
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	return nil
+}
+
+// loadACLEntry aclKeyに保存されたvalidator.ACLEntryを読み込む。未登録の場合は空のACLEntryを返す
+func loadACLEntry(stub shim.ChaincodeStubInterface, myFunc string, aclKey string) (validator.ACLEntry, error) {
+	var acl validator.ACLEntry
+
+	aclBytes, err := stub.GetState(aclKey)
+	if err != nil {
+		msg := "[" + myFunc + "] GetState(aclKey) fail, Error: " + err.Error()
+		log.Print(msg)
+		return acl, fmt.Errorf(msg)
+	}
+	if aclBytes == nil {
+		return acl, nil
+	}
+
+	err = json.Unmarshal(aclBytes, &acl)
+	if err != nil {
+		msg := "[" + myFunc + "] json.Unmarshal(acl) fail, Error: " + err.Error()
+		log.Print(msg)
+		return acl, fmt.Errorf(msg)
+	}
+
+	return acl, nil
+}
+
+// saveACLEntry aclKeyにvalidator.ACLEntryを保存する
+func saveACLEntry(stub shim.ChaincodeStubInterface, myFunc string, aclKey string, acl validator.ACLEntry) error {
+	aclBytes, err := json.Marshal(acl)
+	if err != nil {
+		msg := "[" + myFunc + "] json.Marshal(acl) fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	err = stub.PutState(aclKey, aclBytes)
+	if err != nil {
+		msg := "[" + myFunc + "] PutState(aclKey) fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	return nil
+}
+
+// regist (or replace) the JSON Schema used to validate payloads of the given eventType
+//
+// スキーマはWorldStateに記録するため、チャネル上の全ピアが同一内容を参照でき、裏書きの非決定性を避けられる。
+//
+// @param ctx the transaction context
+// @param eventType the CDL event type the schema applies to
+// @param schemaJson the JSON Schema document
+func (cc *CdlChainCode) RegistCDLEventSchema(ctx contractapi.TransactionContextInterface, eventType string, schemaJson string) error {
+	myFunc := "RegistCDLEventSchema"
+	stub := ctx.GetStub()
+
+	log.Print("cdl-chaincode : RegistCDLEventSchema() called eventType=" + eventType)
+
+	err := requireBootstrapAdmin(ctx, myFunc)
+	if err != nil {
+		return err
+	}
+
+	err = stub.PutState(validator.SchemaKey(eventType), []byte(schemaJson))
+	if err != nil {
+		msg := "[" + myFunc + "] PutState(schemaKey) fail, Error: " + err.Error()
+		log.Print(msg)
+		return fmt.Errorf(msg)
+	}
+
+	log.Print("cdl-chaincode : RegistCDLEventSchema() end eventType=" + eventType)
+
+	// 異常が無ければ正常復帰する
+	return nil
+}
+
+// grant an MSP the right to register/update CDL Events of the given eventType
+//
+// @param ctx the transaction context
+// @param eventType the CDL event type to grant the role for
+// @param mspId the MSP ID to grant the role to
+func (cc *CdlChainCode) GrantCDLEventRole(ctx contractapi.TransactionContextInterface, eventType string, mspId string) error {
+	myFunc := "GrantCDLEventRole"
+	stub := ctx.GetStub()
+
+	log.Print("cdl-chaincode : GrantCDLEventRole() called eventType=" + eventType + " mspId=" + mspId)
+
+	err := requireBootstrapAdmin(ctx, myFunc)
+	if err != nil {
+		return err
+	}
+
+	aclKey := validator.ACLKey(eventType)
+	acl, err := loadACLEntry(stub, myFunc, aclKey)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range acl.AllowedMSPIDs {
+		if existing == mspId {
+			// 既に許可済みの場合は何もしない
+			log.Print("cdl-chaincode : GrantCDLEventRole() end eventType=" + eventType + " mspId=" + mspId)
+			return nil
+		}
+	}
+	acl.AllowedMSPIDs = append(acl.AllowedMSPIDs, mspId)
+
+	err = saveACLEntry(stub, myFunc, aclKey, acl)
+	if err != nil {
+		return err
+	}
+
+	log.Print("cdl-chaincode : GrantCDLEventRole() end eventType=" + eventType + " mspId=" + mspId)
+
+	// 異常が無ければ正常復帰する
+	return nil
+}
+
+// revoke an MSP's right to register/update CDL Events of the given eventType
+//
+// @param ctx the transaction context
+// @param eventType the CDL event type to revoke the role for
+// @param mspId the MSP ID to revoke the role from
+func (cc *CdlChainCode) RevokeCDLEventRole(ctx contractapi.TransactionContextInterface, eventType string, mspId string) error {
+	myFunc := "RevokeCDLEventRole"
+	stub := ctx.GetStub()
+
+	log.Print("cdl-chaincode : RevokeCDLEventRole() called eventType=" + eventType + " mspId=" + mspId)
+
+	err := requireBootstrapAdmin(ctx, myFunc)
+	if err != nil {
+		return err
+	}
+
+	aclKey := validator.ACLKey(eventType)
+	acl, err := loadACLEntry(stub, myFunc, aclKey)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]string, 0, len(acl.AllowedMSPIDs))
+	for _, existing := range acl.AllowedMSPIDs {
+		if existing != mspId {
+			remaining = append(remaining, existing)
+		}
+	}
+	acl.AllowedMSPIDs = remaining
+
+	err = saveACLEntry(stub, myFunc, aclKey, acl)
+	if err != nil {
+		return err
+	}
+
+	log.Print("cdl-chaincode : RevokeCDLEventRole() end eventType=" + eventType + " mspId=" + mspId)
+
+	// 異常が無ければ正常復帰する
+	return nil
+}
+
 //---------------------------------
 // query系関数
 //---------------------------------
@@ -268,6 +792,67 @@ func (cc *CdlChainCode) QueryCDLEventByRichQuery(ctx contractapi.TransactionCont
 	return responseBuf.String(), nil
 }
 
+// CDLEventQueryResult ページネーション付きリッチクエリの応答を表す構造体
+type CDLEventQueryResult struct {
+	Records             []json.RawMessage `json:"records"`
+	FetchedRecordsCount int32             `json:"fetchedRecordsCount"`
+	Bookmark            string            `json:"bookmark"`
+}
+
+// rich query CDL Event from the Block-Chain with pagination
+//
+// @param ctx the transaction context
+// @param query the query
+// @param pageSize the number of records to fetch per page
+// @param bookmark the bookmark returned by a previous call (empty string for the first page)
+// @return CDL Events and pagination metadata (Json String)
+func (cc *CdlChainCode) QueryCDLEventByRichQueryWithPagination(ctx contractapi.TransactionContextInterface, query string, pageSize int32, bookmark string) (string, error) {
+	myFunc := "QueryCDLEventByRichQueryWithPagination"
+	stub := ctx.GetStub()
+
+	log.Print("cdl-chaincode : QueryCDLEventByRichQueryWithPagination() called query=" + query)
+
+	// ページネーション付きリッチクエリ
+	resultsIterator, responseMetadata, err := stub.GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		msg := fmt.Sprintf("["+myFunc+"] "+
+			"GetQueryResultWithPagination(query) %v, Error: "+ err.Error(), query)
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+	defer resultsIterator.Close()
+
+	// 応答レスポンスを構築
+	records := make([]json.RawMessage, 0)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			msg := fmt.Sprintf("["+myFunc+"] "+
+				"Next() %v, Error: "+ err.Error(), queryResponse.Key)
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+		records = append(records, json.RawMessage(queryResponse.Value))
+	}
+
+	result := CDLEventQueryResult{
+		Records:             records,
+		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
+		Bookmark:            responseMetadata.Bookmark,
+	}
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		msg := "[" + myFunc + "] json.Marshal(result) Error: " + err.Error()
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+
+	log.Print("cdl-chaincode : QueryCDLEventByRichQueryWithPagination() end query=" + query)
+
+	// 異常が無ければ正常復帰する
+	return string(resultBytes), nil
+}
+
 // query CDL Events by eventid's array from the Block-Chain
 //
 // @param ctx the transaction context
@@ -329,6 +914,85 @@ func (cc *CdlChainCode) QueryCDLEventByArray(ctx contractapi.TransactionContextI
 	return responseBuf.String(), nil
 }
 
+// query CDL Events by a sub-range [offset, offset+limit) of an eventid's array from the Block-Chain
+//
+// @param ctx the transaction context
+// @param eventidarray a string representing an array of eventids
+// @param offset the index of the first eventid to fetch
+// @param limit the maximum number of eventids to fetch (0 means "until the end of the array")
+// @return CDL Events (Json String)
+func (cc *CdlChainCode) QueryCDLEventByArrayWithPagination(ctx contractapi.TransactionContextInterface, eventidarray string, offset int32, limit int32) (string, error) {
+	myFunc := "QueryCDLEventByArrayWithPagination"
+	stub := ctx.GetStub()
+
+	log.Print("cdl-chaincode : QueryCDLEventByArrayWithPagination() called eventidarray=" + eventidarray)
+
+	// イベントID配列の解析
+	var eventidarrayStr []string
+	err := json.Unmarshal([]byte(eventidarray), &eventidarrayStr)
+	if err != nil {
+		msg := "[" + myFunc + "] json.Unmarshal(eventidarray) Error: " + err.Error()
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+	// イベントID配列が空配列の場合、エラー
+	if len(eventidarrayStr) == 0 {
+		msg := "[" + myFunc + "] The specified eventid's size is zero."
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+	// offset/limitの範囲チェック
+	if offset < 0 || limit < 0 {
+		msg := fmt.Sprintf("["+myFunc+"] offset and limit must not be negative. offset=%d limit=%d", offset, limit)
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+	if int(offset) >= len(eventidarrayStr) {
+		msg := fmt.Sprintf("["+myFunc+"] offset %d is out of range for eventid's size %d", offset, len(eventidarrayStr))
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+	end := int(offset) + int(limit)
+	if limit == 0 || end > len(eventidarrayStr) {
+		end = len(eventidarrayStr)
+	}
+	targetKeys := eventidarrayStr[offset:end]
+
+	// 応答レスポンスを構築
+	var responseBuf bytes.Buffer
+	responseBuf.WriteString("[\n")
+
+	first := true
+	for _, key := range targetKeys {
+		// イベントが未登録である場合はエラー
+		state, err := stub.GetState(key)
+		if err != nil {
+			msg := fmt.Sprintf("["+myFunc+"] "+
+				"GetState(key) %v, Error: "+ err.Error(), key)
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+		if state == nil {
+			msg := fmt.Sprintf("cdleventid '%s' does not exist", key)
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+
+		if first == false {
+			responseBuf.WriteString(",\n")
+		} else {
+			first = false
+		}
+		responseBuf.WriteString(string(state))
+	}
+	responseBuf.WriteString("\n]")
+
+	log.Print("cdl-chaincode : QueryCDLEventByArrayWithPagination() end eventidarray=" + eventidarray)
+
+	// 異常が無ければ正常復帰する
+	return responseBuf.String(), nil
+}
+
 // query CDL Event from the Block-Chain by range
 //
 // (現状、CDLから呼び出すメソッドではなく、
@@ -377,6 +1041,166 @@ func (cc *CdlChainCode) QueryCDLEventByRange(ctx contractapi.TransactionContextI
 	return responseBuf.String(), nil
 }
 
+// CDLEventHistoryEntry キーの変更履歴の1エントリを表す構造体
+type CDLEventHistoryEntry struct {
+	TxId      string `json:"txId"`
+	Timestamp string `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+	Value     string `json:"value"`
+}
+
+// fetchCDLEventHistoryEntries 指定したキーの変更履歴を古い順に取得する
+//
+// GetHistoryForKeyは新しい順に結果を返すため、ここで古い順に並び替える
+func fetchCDLEventHistoryEntries(stub shim.ChaincodeStubInterface, myFunc string, key string) ([]CDLEventHistoryEntry, error) {
+	resultsIterator, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		msg := fmt.Sprintf("["+myFunc+"] "+
+			"GetHistoryForKey(key) %v, Error: "+ err.Error(), key)
+		log.Print(msg)
+		return nil, fmt.Errorf(msg)
+	}
+	defer resultsIterator.Close()
+
+	entries := make([]CDLEventHistoryEntry, 0)
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			msg := "[" + myFunc + "] Next() Error: " + err.Error()
+			log.Print(msg)
+			return nil, fmt.Errorf(msg)
+		}
+		entries = append(entries, CDLEventHistoryEntry{
+			TxId:      modification.TxId,
+			Timestamp: time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos)).UTC().Format(time.RFC3339Nano),
+			IsDelete:  modification.IsDelete,
+			Value:     string(modification.Value),
+		})
+	}
+
+	// 古い順に並び替える
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	return entries, nil
+}
+
+// query the change history of a CDL Event key from the Block-Chain
+//
+// @param ctx the transaction context
+// @param key the key
+// @return history of the CDL Event, ordered oldest to newest (Json String)
+func (cc *CdlChainCode) QueryCDLEventHistory(ctx contractapi.TransactionContextInterface, key string) (string, error) {
+	myFunc := "QueryCDLEventHistory"
+	stub := ctx.GetStub()
+
+	log.Print("cdl-chaincode : QueryCDLEventHistory() called key=" + key)
+
+	entries, err := fetchCDLEventHistoryEntries(stub, myFunc, key)
+	if err != nil {
+		return "", err
+	}
+
+	resultBytes, err := json.Marshal(entries)
+	if err != nil {
+		msg := "[" + myFunc + "] json.Marshal(entries) Error: " + err.Error()
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+
+	log.Print("cdl-chaincode : QueryCDLEventHistory() end key=" + key)
+
+	// 異常が無ければ正常復帰する
+	return string(resultBytes), nil
+}
+
+// query a sub-range [fromTxId, toTxId] of the change history of a CDL Event key from the Block-Chain
+//
+// @param ctx the transaction context
+// @param key the key
+// @param fromTxId the txId to start from (inclusive, empty string means the oldest entry)
+// @param toTxId the txId to end at (inclusive, empty string means the newest entry)
+// @return history of the CDL Event, ordered oldest to newest (Json String)
+func (cc *CdlChainCode) QueryCDLEventHistoryRange(ctx contractapi.TransactionContextInterface, key string, fromTxId string, toTxId string) (string, error) {
+	myFunc := "QueryCDLEventHistoryRange"
+	stub := ctx.GetStub()
+
+	log.Print("cdl-chaincode : QueryCDLEventHistoryRange() called key=" + key)
+
+	entries, err := fetchCDLEventHistoryEntries(stub, myFunc, key)
+	if err != nil {
+		return "", err
+	}
+
+	// 履歴が存在せず、かつfromTxId/toTxIdの指定も無い場合は、
+	// QueryCDLEventHistoryと同様に空配列を返す
+	// (fromTxId/toTxIdが指定されているにもかかわらず履歴が無い場合は、
+	//  この後のfromIndex/toIndexの探索で「not found」として正しく扱われる)
+	if len(entries) == 0 && fromTxId == "" && toTxId == "" {
+		resultBytes, err := json.Marshal(entries)
+		if err != nil {
+			msg := "[" + myFunc + "] json.Marshal(entries) Error: " + err.Error()
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+
+		log.Print("cdl-chaincode : QueryCDLEventHistoryRange() end key=" + key)
+
+		return string(resultBytes), nil
+	}
+
+	fromIndex := 0
+	if fromTxId != "" {
+		fromIndex = -1
+		for i, entry := range entries {
+			if entry.TxId == fromTxId {
+				fromIndex = i
+				break
+			}
+		}
+		if fromIndex == -1 {
+			msg := fmt.Sprintf("["+myFunc+"] fromTxId '%s' not found in the history of key '%s'", fromTxId, key)
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+	}
+
+	toIndex := len(entries) - 1
+	if toTxId != "" {
+		toIndex = -1
+		for i, entry := range entries {
+			if entry.TxId == toTxId {
+				toIndex = i
+				break
+			}
+		}
+		if toIndex == -1 {
+			msg := fmt.Sprintf("["+myFunc+"] toTxId '%s' not found in the history of key '%s'", toTxId, key)
+			log.Print(msg)
+			return "", fmt.Errorf(msg)
+		}
+	}
+
+	if fromIndex > toIndex {
+		msg := fmt.Sprintf("["+myFunc+"] fromTxId '%s' is newer than toTxId '%s'", fromTxId, toTxId)
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+
+	resultBytes, err := json.Marshal(entries[fromIndex : toIndex+1])
+	if err != nil {
+		msg := "[" + myFunc + "] json.Marshal(entries) Error: " + err.Error()
+		log.Print(msg)
+		return "", fmt.Errorf(msg)
+	}
+
+	log.Print("cdl-chaincode : QueryCDLEventHistoryRange() end key=" + key)
+
+	// 異常が無ければ正常復帰する
+	return string(resultBytes), nil
+}
+
 // main関数
 //
 // チェーンコード起動時のエントリポイント
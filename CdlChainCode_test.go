@@ -0,0 +1,478 @@
+/*
+ * CdlChainCode_test.go
+ *
+ * CdlChainCode.goの単体テスト
+ *
+ * COPYRIGHT 2021 Fujitsu Limited
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/CADDE-sip/cdl-chaincode-go/mocks"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// TestQueryCDLEventByRichQueryWithPagination_BookmarkRoundTrip は、1ページ目の応答に
+// 含まれるbookmarkを2ページ目の呼び出しにそのまま引き継げることを確認する。
+func TestQueryCDLEventByRichQueryWithPagination_BookmarkRoundTrip(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		GetQueryResultWithPaginationIterator: mocks.NewStateQueryIterator(
+			&queryresult.KV{Key: "event-1", Value: []byte(`{"eventType":"foo"}`)},
+		),
+		GetQueryResultWithPaginationMetadata: &peer.QueryResponseMetadata{
+			FetchedRecordsCount: 1,
+			Bookmark:            "bookmark-page-2",
+		},
+	}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	cc := new(CdlChainCode)
+	result, err := cc.QueryCDLEventByRichQueryWithPagination(ctx, `{"selector":{}}`, 1, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed CDLEventQueryResult
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if parsed.Bookmark != "bookmark-page-2" {
+		t.Fatalf("expected bookmark 'bookmark-page-2', got %q", parsed.Bookmark)
+	}
+	if parsed.FetchedRecordsCount != 1 {
+		t.Fatalf("expected fetchedRecordsCount 1, got %d", parsed.FetchedRecordsCount)
+	}
+	if len(parsed.Records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(parsed.Records))
+	}
+
+	// 2ページ目は1ページ目の応答で返却されたbookmarkを引き継いで取得する
+	_, err = cc.QueryCDLEventByRichQueryWithPagination(ctx, `{"selector":{}}`, 1, parsed.Bookmark)
+	if err != nil {
+		t.Fatalf("unexpected error on page 2: %v", err)
+	}
+	if stub.GetQueryResultWithPaginationBookmark != parsed.Bookmark {
+		t.Fatalf("expected the bookmark from page 1 to be passed to GetQueryResultWithPagination, got %q", stub.GetQueryResultWithPaginationBookmark)
+	}
+}
+
+// TestQueryCDLEventByRichQueryWithPagination_Error は、GetQueryResultWithPaginationが
+// 失敗した場合にエラーが呼び出し元に伝播することを確認する。
+func TestQueryCDLEventByRichQueryWithPagination_Error(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		GetQueryResultWithPaginationErr: errors.New("boom"),
+	}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	cc := new(CdlChainCode)
+	_, err := cc.QueryCDLEventByRichQueryWithPagination(ctx, `{"selector":{}}`, 1, "")
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+// TestQueryCDLEventHistory_OldestToNewestOrder は、GetHistoryForKeyが新しい順に返す結果を
+// QueryCDLEventHistoryが古い順に並び替えて返すことを確認する。
+func TestQueryCDLEventHistory_OldestToNewestOrder(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		GetHistoryForKeyIterator: mocks.NewHistoryQueryIterator(
+			&queryresult.KeyModification{TxId: "tx3", Value: []byte(`{"v":3}`), Timestamp: &timestamp.Timestamp{Seconds: 3}},
+			&queryresult.KeyModification{TxId: "tx2", Value: []byte(`{"v":2}`), Timestamp: &timestamp.Timestamp{Seconds: 2}},
+			&queryresult.KeyModification{TxId: "tx1", Value: []byte(`{"v":1}`), Timestamp: &timestamp.Timestamp{Seconds: 1}},
+		),
+	}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	cc := new(CdlChainCode)
+	result, err := cc.QueryCDLEventHistory(ctx, "event-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.GetHistoryForKeyKey != "event-1" {
+		t.Fatalf("expected GetHistoryForKey to be called with 'event-1', got %q", stub.GetHistoryForKeyKey)
+	}
+
+	var entries []CDLEventHistoryEntry
+	if err := json.Unmarshal([]byte(result), &entries); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	wantOrder := []string{"tx1", "tx2", "tx3"}
+	for i, txId := range wantOrder {
+		if entries[i].TxId != txId {
+			t.Fatalf("expected entries[%d].TxId to be %q, got %q", i, txId, entries[i].TxId)
+		}
+	}
+}
+
+// TestQueryCDLEventHistory_NoHistory は、履歴が存在しないキーに対して
+// 空配列が返ることを確認する。
+func TestQueryCDLEventHistory_NoHistory(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		GetHistoryForKeyIterator: mocks.NewHistoryQueryIterator(),
+	}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	cc := new(CdlChainCode)
+	result, err := cc.QueryCDLEventHistory(ctx, "event-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var entries []CDLEventHistoryEntry
+	if err := json.Unmarshal([]byte(result), &entries); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected 0 entries, got %d", len(entries))
+	}
+}
+
+// TestQueryCDLEventHistoryRange_NoHistory は、履歴が存在しないキーに対してfromTxId/toTxIdの
+// 指定が無い場合に空配列が返ることを確認する(QueryCDLEventHistoryと同じ挙動)。
+func TestQueryCDLEventHistoryRange_NoHistory(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		GetHistoryForKeyIterator: mocks.NewHistoryQueryIterator(),
+	}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	cc := new(CdlChainCode)
+	result, err := cc.QueryCDLEventHistoryRange(ctx, "event-1", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var entries []CDLEventHistoryEntry
+	if err := json.Unmarshal([]byte(result), &entries); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected 0 entries, got %d", len(entries))
+	}
+}
+
+// TestQueryCDLEventHistoryRange_NoHistoryWithTxIdSpecified は、履歴が存在しないキーに対して
+// fromTxId/toTxIdが指定された場合は「not found」エラーになることを確認する。
+func TestQueryCDLEventHistoryRange_NoHistoryWithTxIdSpecified(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		GetHistoryForKeyIterator: mocks.NewHistoryQueryIterator(),
+	}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	cc := new(CdlChainCode)
+	_, err := cc.QueryCDLEventHistoryRange(ctx, "event-1", "tx1", "")
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+// TestQueryCDLEventHistoryRange_Subrange は、fromTxId/toTxIdで指定した範囲の履歴のみが
+// 古い順に返ることを確認する。
+func TestQueryCDLEventHistoryRange_Subrange(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		GetHistoryForKeyIterator: mocks.NewHistoryQueryIterator(
+			&queryresult.KeyModification{TxId: "tx3", Value: []byte(`{"v":3}`), Timestamp: &timestamp.Timestamp{Seconds: 3}},
+			&queryresult.KeyModification{TxId: "tx2", Value: []byte(`{"v":2}`), Timestamp: &timestamp.Timestamp{Seconds: 2}},
+			&queryresult.KeyModification{TxId: "tx1", Value: []byte(`{"v":1}`), Timestamp: &timestamp.Timestamp{Seconds: 1}},
+		),
+	}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	cc := new(CdlChainCode)
+	result, err := cc.QueryCDLEventHistoryRange(ctx, "event-1", "tx1", "tx2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var entries []CDLEventHistoryEntry
+	if err := json.Unmarshal([]byte(result), &entries); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].TxId != "tx1" || entries[1].TxId != "tx2" {
+		t.Fatalf("expected entries [tx1, tx2], got [%s, %s]", entries[0].TxId, entries[1].TxId)
+	}
+}
+
+// TestQueryCDLEventHistoryRange_FromTxIdNotFound は、fromTxIdが履歴中に存在しない場合に
+// エラーになることを確認する。
+func TestQueryCDLEventHistoryRange_FromTxIdNotFound(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		GetHistoryForKeyIterator: mocks.NewHistoryQueryIterator(
+			&queryresult.KeyModification{TxId: "tx1", Value: []byte(`{"v":1}`), Timestamp: &timestamp.Timestamp{Seconds: 1}},
+		),
+	}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	cc := new(CdlChainCode)
+	_, err := cc.QueryCDLEventHistoryRange(ctx, "event-1", "tx-missing", "")
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+// TestQueryCDLEventHistoryRange_FromAfterTo は、fromTxIdがtoTxIdより新しい場合に
+// エラーになることを確認する。
+func TestQueryCDLEventHistoryRange_FromAfterTo(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		GetHistoryForKeyIterator: mocks.NewHistoryQueryIterator(
+			&queryresult.KeyModification{TxId: "tx2", Value: []byte(`{"v":2}`), Timestamp: &timestamp.Timestamp{Seconds: 2}},
+			&queryresult.KeyModification{TxId: "tx1", Value: []byte(`{"v":1}`), Timestamp: &timestamp.Timestamp{Seconds: 1}},
+		),
+	}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	cc := new(CdlChainCode)
+	_, err := cc.QueryCDLEventHistoryRange(ctx, "event-1", "tx2", "tx1")
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+// testMspId は、以下のイベント発行系テストで使用するクライアントのMSP ID。
+const testMspId = "Org1MSP"
+
+// newAuthorizedStub は、eventTypeに対するスキーマ検証・ACLチェックをいずれも
+// 通過できる状態(schema~/acl~が登録済み)のChaincodeStubを生成する。
+func newAuthorizedStub(eventType string) *mocks.ChaincodeStub {
+	schema := `{"type":"object","required":["eventType"],"properties":{"eventType":{"type":"string"}}}`
+	acl := `{"allowedMspIds":["` + testMspId + `"]}`
+	return &mocks.ChaincodeStub{
+		State: map[string][]byte{
+			"schema~" + eventType: []byte(schema),
+			"acl~" + eventType:    []byte(acl),
+		},
+		TxTimestamp: &timestamp.Timestamp{Seconds: 100},
+	}
+}
+
+// newAuthorizedContext は、newAuthorizedStubの内容にtestMspIdを呼び出し元として設定した
+// TransactionContextを生成する。
+func newAuthorizedContext(stub *mocks.ChaincodeStub) *mocks.TransactionContext {
+	return &mocks.TransactionContext{
+		Stub:           stub,
+		ClientIdentity: &mocks.ClientIdentity{MspId: testMspId},
+	}
+}
+
+// TestRegistCDLEvent_EmitsCDLEventRegistered は、RegistCDLEventが前イベント無しで
+// CDLEventRegisteredイベントを発行することを確認する。
+func TestRegistCDLEvent_EmitsCDLEventRegistered(t *testing.T) {
+	stub := newAuthorizedStub("foo")
+	ctx := newAuthorizedContext(stub)
+
+	cc := new(CdlChainCode)
+	err := cc.RegistCDLEvent(ctx, "event-1", `{"eventType":"foo"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(stub.SetEventCalls) != 1 {
+		t.Fatalf("expected 1 SetEvent call, got %d", len(stub.SetEventCalls))
+	}
+	if stub.SetEventCalls[0].Name != CDLEventRegisteredEventName {
+		t.Fatalf("expected event name %q, got %q", CDLEventRegisteredEventName, stub.SetEventCalls[0].Name)
+	}
+
+	var notification CDLEventNotification
+	if err := json.Unmarshal(stub.SetEventCalls[0].Payload, &notification); err != nil {
+		t.Fatalf("failed to unmarshal event payload: %v", err)
+	}
+	if notification.Key != "event-1" {
+		t.Fatalf("expected key 'event-1', got %q", notification.Key)
+	}
+	if notification.MspId != testMspId {
+		t.Fatalf("expected mspId %q, got %q", testMspId, notification.MspId)
+	}
+	if len(notification.Predecessors) != 0 {
+		t.Fatalf("expected no predecessors, got %v", notification.Predecessors)
+	}
+}
+
+// TestRegistCDLEventWithTag_UsesCallerSuppliedEventName は、RegistCDLEventWithTagが
+// 呼び出し元で指定したイベント名で発行することを確認する。
+func TestRegistCDLEventWithTag_UsesCallerSuppliedEventName(t *testing.T) {
+	stub := newAuthorizedStub("foo")
+	ctx := newAuthorizedContext(stub)
+
+	cc := new(CdlChainCode)
+	err := cc.RegistCDLEventWithTag(ctx, "event-1", `{"eventType":"foo"}`, "CustomEventTag")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(stub.SetEventCalls) != 1 {
+		t.Fatalf("expected 1 SetEvent call, got %d", len(stub.SetEventCalls))
+	}
+	if stub.SetEventCalls[0].Name != "CustomEventTag" {
+		t.Fatalf("expected event name 'CustomEventTag', got %q", stub.SetEventCalls[0].Name)
+	}
+}
+
+// TestRegistUpdateCDLEvent_EmitsSortedPredecessors は、RegistUpdateCDLEventが
+// CDLEventUpdatedイベントに前イベントのキーをソート済みで含めることを確認する。
+func TestRegistUpdateCDLEvent_EmitsSortedPredecessors(t *testing.T) {
+	stub := newAuthorizedStub("foo")
+	stub.State["event-b"] = []byte(`{"eventType":"foo"}`)
+	stub.State["event-a"] = []byte(`{"eventType":"foo"}`)
+	ctx := newAuthorizedContext(stub)
+
+	cc := new(CdlChainCode)
+	updates := `{"event-b":"{\"eventType\":\"foo\",\"v\":2}","event-a":"{\"eventType\":\"foo\",\"v\":1}"}`
+	err := cc.RegistUpdateCDLEvent(ctx, "event-new", `{"eventType":"foo"}`, updates)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(stub.SetEventCalls) != 1 {
+		t.Fatalf("expected 1 SetEvent call, got %d", len(stub.SetEventCalls))
+	}
+	if stub.SetEventCalls[0].Name != CDLEventUpdatedEventName {
+		t.Fatalf("expected event name %q, got %q", CDLEventUpdatedEventName, stub.SetEventCalls[0].Name)
+	}
+
+	var notification CDLEventNotification
+	if err := json.Unmarshal(stub.SetEventCalls[0].Payload, &notification); err != nil {
+		t.Fatalf("failed to unmarshal event payload: %v", err)
+	}
+	wantPredecessors := []string{"event-a", "event-b"}
+	if len(notification.Predecessors) != len(wantPredecessors) {
+		t.Fatalf("expected predecessors %v, got %v", wantPredecessors, notification.Predecessors)
+	}
+	for i, key := range wantPredecessors {
+		if notification.Predecessors[i] != key {
+			t.Fatalf("expected predecessors %v, got %v", wantPredecessors, notification.Predecessors)
+		}
+	}
+}
+
+// TestRegistCDLEventBatch_EmitsOneEventPerEntry は、RegistCDLEventBatchがバッチ内の
+// 各エントリについて前イベントの有無に応じたイベント名でチェーンコードイベントを発行することを確認する。
+func TestRegistCDLEventBatch_EmitsOneEventPerEntry(t *testing.T) {
+	stub := newAuthorizedStub("foo")
+	stub.State["pred-1"] = []byte(`{"eventType":"foo"}`)
+	ctx := newAuthorizedContext(stub)
+
+	cc := new(CdlChainCode)
+	batchJson := `{"events":[` +
+		`{"key":"event-1","value":"{\"eventType\":\"foo\"}","predecessors":{}},` +
+		`{"key":"event-2","value":"{\"eventType\":\"foo\"}","predecessors":{"pred-1":"{\"eventType\":\"foo\",\"v\":2}"}}` +
+		`]}`
+	_, err := cc.RegistCDLEventBatch(ctx, batchJson)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(stub.SetEventCalls) != 2 {
+		t.Fatalf("expected 2 SetEvent calls, got %d", len(stub.SetEventCalls))
+	}
+
+	gotEvents := make(map[string]SetEventSummary)
+	for _, call := range stub.SetEventCalls {
+		var notification CDLEventNotification
+		if err := json.Unmarshal(call.Payload, &notification); err != nil {
+			t.Fatalf("failed to unmarshal event payload: %v", err)
+		}
+		gotEvents[notification.Key] = SetEventSummary{Name: call.Name, Predecessors: notification.Predecessors}
+	}
+
+	event1, ok := gotEvents["event-1"]
+	if !ok {
+		t.Fatal("expected a SetEvent call for event-1")
+	}
+	if event1.Name != CDLEventRegisteredEventName || len(event1.Predecessors) != 0 {
+		t.Fatalf("expected event-1 to be CDLEventRegistered with no predecessors, got %+v", event1)
+	}
+
+	event2, ok := gotEvents["event-2"]
+	if !ok {
+		t.Fatal("expected a SetEvent call for event-2")
+	}
+	if event2.Name != CDLEventUpdatedEventName || len(event2.Predecessors) != 1 || event2.Predecessors[0] != "pred-1" {
+		t.Fatalf("expected event-2 to be CDLEventUpdated with predecessors [pred-1], got %+v", event2)
+	}
+}
+
+// SetEventSummary はTestRegistCDLEventBatch_EmitsOneEventPerEntryで、SetEvent呼び出し内容を
+// 比較しやすい形にまとめるための構造体。
+type SetEventSummary struct {
+	Name         string
+	Predecessors []string
+}
+
+// TestRegistCDLEventBatch_DuplicateKeyAcrossEntries は、バッチ内で同一キーが複数回
+// 書き込み対象になっている場合にエラーとなり、事前チェックで打ち切られることを確認する。
+func TestRegistCDLEventBatch_DuplicateKeyAcrossEntries(t *testing.T) {
+	stub := newAuthorizedStub("foo")
+	ctx := newAuthorizedContext(stub)
+
+	cc := new(CdlChainCode)
+	batchJson := `{"events":[` +
+		`{"key":"event-1","value":"{\"eventType\":\"foo\"}","predecessors":{}},` +
+		`{"key":"event-2","value":"{\"eventType\":\"foo\"}","predecessors":{"event-1":"{\"eventType\":\"foo\"}"}}` +
+		`]}`
+	_, err := cc.RegistCDLEventBatch(ctx, batchJson)
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if len(stub.State) != 2 {
+		t.Fatalf("expected no PutState calls beyond the pre-seeded schema/ACL entries, got State=%v", stub.State)
+	}
+	if len(stub.SetEventCalls) != 0 {
+		t.Fatalf("expected no SetEvent calls, got %d", len(stub.SetEventCalls))
+	}
+}
+
+// TestRegistCDLEventBatch_AtomicOnPrecheckFailure は、バッチ内の1件でも事前チェックに
+// 失敗した場合、後続のエントリも含めて一切WorldStateへ書き込まれないことを確認する
+// (all-or-nothingの検証)。
+func TestRegistCDLEventBatch_AtomicOnPrecheckFailure(t *testing.T) {
+	stub := newAuthorizedStub("foo")
+	stub.State["event-2"] = []byte(`{"eventType":"foo"}`) // 既に存在するため事前チェックで失敗する
+	ctx := newAuthorizedContext(stub)
+
+	cc := new(CdlChainCode)
+	batchJson := `{"events":[` +
+		`{"key":"event-1","value":"{\"eventType\":\"foo\"}","predecessors":{}},` +
+		`{"key":"event-2","value":"{\"eventType\":\"foo\"}","predecessors":{}}` +
+		`]}`
+	_, err := cc.RegistCDLEventBatch(ctx, batchJson)
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if _, exists := stub.State["event-1"]; exists {
+		t.Fatal("expected event-1 to not be written when a later entry fails precheck")
+	}
+	if len(stub.SetEventCalls) != 0 {
+		t.Fatalf("expected no SetEvent calls, got %d", len(stub.SetEventCalls))
+	}
+}
+
+// TestRegistCDLEventBatch_PredecessorCollisionAcrossEntries は、あるエントリのKeyと
+// 別エントリのPredecessorsのキーが衝突している場合にエラーとなることを確認する。
+func TestRegistCDLEventBatch_PredecessorCollisionAcrossEntries(t *testing.T) {
+	stub := newAuthorizedStub("foo")
+	stub.State["pred-1"] = []byte(`{"eventType":"foo"}`)
+	ctx := newAuthorizedContext(stub)
+
+	cc := new(CdlChainCode)
+	batchJson := `{"events":[` +
+		`{"key":"pred-1","value":"{\"eventType\":\"foo\"}","predecessors":{}},` +
+		`{"key":"event-2","value":"{\"eventType\":\"foo\"}","predecessors":{"pred-1":"{\"eventType\":\"foo\"}"}}` +
+		`]}`
+	_, err := cc.RegistCDLEventBatch(ctx, batchJson)
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
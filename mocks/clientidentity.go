@@ -0,0 +1,43 @@
+/*
+ * clientidentity.go
+ *
+ * 単体テスト用のcid.ClientIdentityの簡易テストダブル
+ *
+ * COPYRIGHT 2021 Fujitsu Limited
+ */
+
+package mocks
+
+import "crypto/x509"
+
+// ClientIdentity is a lightweight test double for cid.ClientIdentity that
+// always reports the configured MSP ID.
+type ClientIdentity struct {
+	MspId string
+	Err   error
+}
+
+// GetID is not used by this repository's unit tests and always returns an empty string.
+func (ci *ClientIdentity) GetID() (string, error) {
+	return "", ci.Err
+}
+
+// GetMSPID returns the MSP ID configured on this ClientIdentity.
+func (ci *ClientIdentity) GetMSPID() (string, error) {
+	return ci.MspId, ci.Err
+}
+
+// GetAttributeValue is not used by this repository's unit tests and always reports "not found".
+func (ci *ClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	return "", false, ci.Err
+}
+
+// AssertAttributeValue is not used by this repository's unit tests and only returns the configured Err.
+func (ci *ClientIdentity) AssertAttributeValue(attrName string, attrValue string) error {
+	return ci.Err
+}
+
+// GetX509Certificate is not used by this repository's unit tests and always returns nil.
+func (ci *ClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return nil, ci.Err
+}
@@ -0,0 +1,41 @@
+/*
+ * historyqueryiterator.go
+ *
+ * 単体テスト用のshim.HistoryQueryIteratorInterfaceの簡易テストダブル
+ *
+ * COPYRIGHT 2021 Fujitsu Limited
+ */
+
+package mocks
+
+import "github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+
+// HistoryQueryIterator is a lightweight test double for shim.HistoryQueryIteratorInterface
+// that yields a fixed, in-memory slice of key modifications.
+type HistoryQueryIterator struct {
+	results []*queryresult.KeyModification
+	index   int
+}
+
+// NewHistoryQueryIterator builds a HistoryQueryIterator that yields the given
+// key modifications in order (as GetHistoryForKey itself would: newest first).
+func NewHistoryQueryIterator(results ...*queryresult.KeyModification) *HistoryQueryIterator {
+	return &HistoryQueryIterator{results: results}
+}
+
+// HasNext returns whether there are more results to iterate over.
+func (it *HistoryQueryIterator) HasNext() bool {
+	return it.index < len(it.results)
+}
+
+// Next returns the next result in the iterator.
+func (it *HistoryQueryIterator) Next() (*queryresult.KeyModification, error) {
+	result := it.results[it.index]
+	it.index++
+	return result, nil
+}
+
+// Close is a no-op for this in-memory test double.
+func (it *HistoryQueryIterator) Close() error {
+	return nil
+}
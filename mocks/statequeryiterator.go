@@ -0,0 +1,40 @@
+/*
+ * statequeryiterator.go
+ *
+ * 単体テスト用のshim.StateQueryIteratorInterfaceの簡易テストダブル
+ *
+ * COPYRIGHT 2021 Fujitsu Limited
+ */
+
+package mocks
+
+import "github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+
+// StateQueryIterator is a lightweight test double for shim.StateQueryIteratorInterface
+// that yields a fixed, in-memory slice of results.
+type StateQueryIterator struct {
+	results []*queryresult.KV
+	index   int
+}
+
+// NewStateQueryIterator builds a StateQueryIterator that yields the given key/value results in order.
+func NewStateQueryIterator(results ...*queryresult.KV) *StateQueryIterator {
+	return &StateQueryIterator{results: results}
+}
+
+// HasNext returns whether there are more results to iterate over.
+func (it *StateQueryIterator) HasNext() bool {
+	return it.index < len(it.results)
+}
+
+// Next returns the next result in the iterator.
+func (it *StateQueryIterator) Next() (*queryresult.KV, error) {
+	result := it.results[it.index]
+	it.index++
+	return result, nil
+}
+
+// Close is a no-op for this in-memory test double.
+func (it *StateQueryIterator) Close() error {
+	return nil
+}
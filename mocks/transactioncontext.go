@@ -0,0 +1,38 @@
+/*
+ * transactioncontext.go
+ *
+ * 単体テスト用のcontractapi.TransactionContextInterfaceの簡易テストダブル
+ *
+ * COPYRIGHT 2021 Fujitsu Limited
+ */
+
+package mocks
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransactionContext is a lightweight test double for contractapi.TransactionContextInterface.
+//
+// It embeds the real interface (as a nil value) so every method not stubbed
+// here still satisfies the interface at compile time; this package only
+// overrides GetStub() and GetClientIdentity(), which is all that this
+// repository's unit tests need.
+type TransactionContext struct {
+	contractapi.TransactionContextInterface
+
+	Stub           *ChaincodeStub
+	ClientIdentity *ClientIdentity
+}
+
+// GetStub returns the ChaincodeStub configured on this TransactionContext.
+func (ctx *TransactionContext) GetStub() shim.ChaincodeStubInterface {
+	return ctx.Stub
+}
+
+// GetClientIdentity returns the ClientIdentity configured on this TransactionContext.
+func (ctx *TransactionContext) GetClientIdentity() cid.ClientIdentity {
+	return ctx.ClientIdentity
+}
@@ -0,0 +1,175 @@
+/*
+ * validator.go
+ *
+ * CDLイベントのペイロード検証とMSPベースのアクセス制御を行うバリデータ群
+ *
+ * COPYRIGHT 2021 Fujitsu Limited
+ */
+
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ACLKeyPrefix WorldState上でACLエントリを保持するキーのプレフィックス
+const ACLKeyPrefix = "acl~"
+
+// EventTypeField ペイロード中でイベント種別を表すフィールド名
+const EventTypeField = "eventType"
+
+// Validator RegistCDLEvent/RegistUpdateCDLEventの実行前にペイロードを検証するインタフェース
+type Validator interface {
+	Validate(ctx contractapi.TransactionContextInterface, jsonString string) error
+}
+
+// Chain 複数のValidatorを先頭から順に実行する合成バリデータ
+type Chain struct {
+	validators []Validator
+}
+
+// NewChain 複数のValidatorからChainを構築する
+func NewChain(validators ...Validator) *Chain {
+	return &Chain{validators: validators}
+}
+
+// Validate 登録済みのValidatorを先頭から順に実行し、最初のエラーで打ち切る
+func (c *Chain) Validate(ctx contractapi.TransactionContextInterface, jsonString string) error {
+	for _, v := range c.validators {
+		if err := v.Validate(ctx, jsonString); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventTypeOf jsonStringからEventTypeFieldの値を取り出す
+func eventTypeOf(myFunc string, jsonString string) (string, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonString), &payload); err != nil {
+		return "", fmt.Errorf("[" + myFunc + "] json.Unmarshal(jsonString) Error: " + err.Error())
+	}
+
+	eventType, ok := payload[EventTypeField].(string)
+	if !ok || eventType == "" {
+		return "", fmt.Errorf("[" + myFunc + "] payload is missing the required '" + EventTypeField + "' field")
+	}
+
+	return eventType, nil
+}
+
+// SchemaKeyPrefix WorldState上でJSON Schemaを保持するキーのプレフィックス
+const SchemaKeyPrefix = "schema~"
+
+// SchemaKey eventTypeに対応するJSON SchemaのWorldStateキーを返す
+func SchemaKey(eventType string) string {
+	return SchemaKeyPrefix + eventType
+}
+
+// SchemaValidator WorldState上に登録されたJSON Schemaでペイロードを検証する
+//
+// スキーマはチャネル上の全ピアが共有するWorldState(キー SchemaKey(eventType))から読み込む。
+// ピアのローカルファイルシステムから読み込むと、ピアごとにファイルが一致する保証が無く、
+// 同一トランザクションの裏書きがピア間で食い違う(非決定的になる)おそれがあるため避ける。
+type SchemaValidator struct {
+}
+
+// NewSchemaValidator SchemaValidatorを生成する
+func NewSchemaValidator() *SchemaValidator {
+	return &SchemaValidator{}
+}
+
+// Validate jsonStringのeventTypeフィールドに対応するJSON Schemaで検証する
+func (v *SchemaValidator) Validate(ctx contractapi.TransactionContextInterface, jsonString string) error {
+	myFunc := "SchemaValidator"
+
+	eventType, err := eventTypeOf(myFunc, jsonString)
+	if err != nil {
+		return err
+	}
+
+	schemaBytes, err := ctx.GetStub().GetState(SchemaKey(eventType))
+	if err != nil {
+		return fmt.Errorf("[" + myFunc + "] GetState(schemaKey) Error: " + err.Error())
+	}
+	if schemaBytes == nil {
+		return fmt.Errorf("["+myFunc+"] no schema registered for eventType '%s'", eventType)
+	}
+
+	schemaLoader := gojsonschema.NewBytesLoader(schemaBytes)
+	documentLoader := gojsonschema.NewStringLoader(jsonString)
+	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	if err != nil {
+		return fmt.Errorf("[" + myFunc + "] gojsonschema.Validate() Error: " + err.Error())
+	}
+	if !result.Valid() {
+		msg := fmt.Sprintf("["+myFunc+"] payload does not conform to schema for eventType '%s':", eventType)
+		for _, resultError := range result.Errors() {
+			msg += " " + resultError.String() + ";"
+		}
+		return fmt.Errorf(msg)
+	}
+
+	return nil
+}
+
+// ACLEntry acl~<eventType> キーに保存するACLの形式
+type ACLEntry struct {
+	AllowedMSPIDs []string `json:"allowedMspIds"`
+}
+
+// ACLKey eventTypeに対応するACLエントリのWorldStateキーを返す
+func ACLKey(eventType string) string {
+	return ACLKeyPrefix + eventType
+}
+
+// IdentityValidator 呼び出し元のMSP IDがeventTypeに対する登録権限を持つかを、
+// WorldState上のACL(キー ACLKey(eventType))と照合して検証する
+type IdentityValidator struct {
+}
+
+// NewIdentityValidator IdentityValidatorを生成する
+func NewIdentityValidator() *IdentityValidator {
+	return &IdentityValidator{}
+}
+
+// Validate 呼び出し元のMSP IDがeventTypeのACLに含まれるかを検証する
+func (v *IdentityValidator) Validate(ctx contractapi.TransactionContextInterface, jsonString string) error {
+	myFunc := "IdentityValidator"
+
+	eventType, err := eventTypeOf(myFunc, jsonString)
+	if err != nil {
+		return err
+	}
+
+	mspId, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("[" + myFunc + "] GetClientIdentity().GetMSPID() Error: " + err.Error())
+	}
+
+	stub := ctx.GetStub()
+	aclBytes, err := stub.GetState(ACLKey(eventType))
+	if err != nil {
+		return fmt.Errorf("[" + myFunc + "] GetState(aclKey) Error: " + err.Error())
+	}
+	if aclBytes == nil {
+		return fmt.Errorf("["+myFunc+"] no ACL registered for eventType '%s'", eventType)
+	}
+
+	var acl ACLEntry
+	if err := json.Unmarshal(aclBytes, &acl); err != nil {
+		return fmt.Errorf("[" + myFunc + "] json.Unmarshal(acl) Error: " + err.Error())
+	}
+
+	for _, allowed := range acl.AllowedMSPIDs {
+		if allowed == mspId {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("["+myFunc+"] MSP '%s' is not authorized to register events of type '%s'", mspId, eventType)
+}
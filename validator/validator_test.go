@@ -0,0 +1,140 @@
+/*
+ * validator_test.go
+ *
+ * validator.goの単体テスト
+ *
+ * COPYRIGHT 2021 Fujitsu Limited
+ */
+
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/CADDE-sip/cdl-chaincode-go/mocks"
+)
+
+const testEventType = "foo"
+const testMspId = "Org1MSP"
+const testSchema = `{"type":"object","required":["eventType"],"properties":{"eventType":{"type":"string"}}}`
+
+func newStubWithSchema(schema string) *mocks.ChaincodeStub {
+	return &mocks.ChaincodeStub{
+		State: map[string][]byte{
+			SchemaKey(testEventType): []byte(schema),
+		},
+	}
+}
+
+// TestSchemaValidator_Valid は、WorldStateに登録されたJSON Schemaに適合する
+// ペイロードが検証を通過することを確認する。
+func TestSchemaValidator_Valid(t *testing.T) {
+	stub := newStubWithSchema(testSchema)
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	v := NewSchemaValidator()
+	err := v.Validate(ctx, `{"eventType":"foo"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestSchemaValidator_SchemaViolation は、登録されたJSON Schemaに違反するペイロードが
+// 拒否されることを確認する。
+func TestSchemaValidator_SchemaViolation(t *testing.T) {
+	stub := newStubWithSchema(testSchema)
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	v := NewSchemaValidator()
+	err := v.Validate(ctx, `{"other":"value"}`)
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+// TestSchemaValidator_NoSchemaRegistered は、eventTypeに対応するスキーマが
+// WorldStateに未登録の場合に拒否されることを確認する。
+func TestSchemaValidator_NoSchemaRegistered(t *testing.T) {
+	stub := &mocks.ChaincodeStub{State: map[string][]byte{}}
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	v := NewSchemaValidator()
+	err := v.Validate(ctx, `{"eventType":"foo"}`)
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+// TestIdentityValidator_Allowed は、呼び出し元のMSP IDがACLに含まれる場合に
+// 検証を通過することを確認する。
+func TestIdentityValidator_Allowed(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		State: map[string][]byte{
+			ACLKey(testEventType): []byte(`{"allowedMspIds":["` + testMspId + `"]}`),
+		},
+	}
+	ctx := &mocks.TransactionContext{
+		Stub:           stub,
+		ClientIdentity: &mocks.ClientIdentity{MspId: testMspId},
+	}
+
+	v := NewIdentityValidator()
+	err := v.Validate(ctx, `{"eventType":"foo"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestIdentityValidator_NotAllowed は、呼び出し元のMSP IDがACLに含まれない場合に
+// 拒否されることを確認する。
+func TestIdentityValidator_NotAllowed(t *testing.T) {
+	stub := &mocks.ChaincodeStub{
+		State: map[string][]byte{
+			ACLKey(testEventType): []byte(`{"allowedMspIds":["Org2MSP"]}`),
+		},
+	}
+	ctx := &mocks.TransactionContext{
+		Stub:           stub,
+		ClientIdentity: &mocks.ClientIdentity{MspId: testMspId},
+	}
+
+	v := NewIdentityValidator()
+	err := v.Validate(ctx, `{"eventType":"foo"}`)
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+// TestIdentityValidator_NoACLRegistered は、eventTypeに対応するACLが
+// WorldStateに未登録の場合に拒否されることを確認する。
+func TestIdentityValidator_NoACLRegistered(t *testing.T) {
+	stub := &mocks.ChaincodeStub{State: map[string][]byte{}}
+	ctx := &mocks.TransactionContext{
+		Stub:           stub,
+		ClientIdentity: &mocks.ClientIdentity{MspId: testMspId},
+	}
+
+	v := NewIdentityValidator()
+	err := v.Validate(ctx, `{"eventType":"foo"}`)
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+// TestChain_StopsAtFirstError は、Chainが先頭のValidatorでエラーになった場合、
+// 後続のValidatorを実行せずに打ち切ることを確認する。
+func TestChain_StopsAtFirstError(t *testing.T) {
+	stub := newStubWithSchema(testSchema)
+	ctx := &mocks.TransactionContext{Stub: stub}
+
+	chain := NewChain(NewSchemaValidator(), NewIdentityValidator())
+	// スキーマ違反のペイロードなので、ACLが未登録でもSchemaValidatorのエラーで打ち切られるはず
+	err := chain.Validate(ctx, `{"other":"value"}`)
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if !strings.HasPrefix(err.Error(), "[SchemaValidator]") {
+		t.Fatalf("expected the chain to stop at SchemaValidator's error, got %v", err)
+	}
+}